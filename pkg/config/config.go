@@ -0,0 +1,45 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config parses the exporter's YAML configuration file and
+// installs any per-probe settings it contains.
+package config
+
+import (
+	"gopkg.in/yaml.v2"
+
+	"github.com/prometheus-community/fortigate_exporter/pkg/probe"
+)
+
+// Config is the root of the exporter's YAML configuration file.
+type Config struct {
+	Probes ProbeConfig `yaml:"probes"`
+}
+
+// ProbeConfig holds the per-probe configuration sections that individual
+// probe packages expose a setter for.
+type ProbeConfig struct {
+	HAChecksum probe.HAChecksumConfig `yaml:"ha_checksum"`
+}
+
+// Load parses exporter configuration from YAML and installs the
+// per-probe settings it contains, such as HAChecksumConfig's cardinality
+// opt-out.
+func Load(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	probe.SetHAChecksumConfig(cfg.Probes.HAChecksum)
+	return &cfg, nil
+}
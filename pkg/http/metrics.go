@@ -0,0 +1,115 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package http
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// requestDurationBuckets are the classic histogram boundaries used for
+// fortigate_api_request_duration_seconds. A true native (sparse)
+// histogram needs to be a Collector registered against the registry a
+// target's /probe scrape actually gathers from; this package has no
+// access to that registry (it isn't threaded through the probe
+// framework), so latencies accumulate here as classic bucket counts
+// instead and are snapshotted on demand via RequestDurationMetrics for a
+// probe to return.
+var requestDurationBuckets = prometheus.DefBuckets
+
+type requestDurationAccumulator struct {
+	mu      sync.Mutex
+	buckets map[string]map[float64]uint64 // endpoint -> bucket upper bound -> cumulative count
+	sums    map[string]float64
+	counts  map[string]uint64
+}
+
+var requestDurations = &requestDurationAccumulator{
+	buckets: map[string]map[float64]uint64{},
+	sums:    map[string]float64{},
+	counts:  map[string]uint64{},
+}
+
+func (a *requestDurationAccumulator) observe(endpoint string, seconds float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.buckets[endpoint]; !ok {
+		a.buckets[endpoint] = map[float64]uint64{}
+	}
+	for _, b := range requestDurationBuckets {
+		if seconds <= b {
+			a.buckets[endpoint][b]++
+		}
+	}
+	a.sums[endpoint] += seconds
+	a.counts[endpoint]++
+}
+
+// observeRequestDuration records how long a call to endpoint took.
+func observeRequestDuration(endpoint string, d time.Duration) {
+	requestDurations.observe(endpoint, d.Seconds())
+}
+
+// timeRequest is a small helper for wrapping a FortiHTTP.Get call:
+//
+//	defer timeRequest(endpoint)()
+func timeRequest(endpoint string) func() {
+	start := time.Now()
+	return func() {
+		observeRequestDuration(endpoint, time.Since(start))
+	}
+}
+
+// TimedGet calls c.Get and records its latency in
+// fortigate_api_request_duration_seconds under the endpoint label.
+// Probes should call this instead of c.Get directly; see
+// pkg/probe/system_ha_checksum.go and friends.
+func TimedGet(c FortiHTTP, endpoint, query string, result interface{}) error {
+	defer timeRequest(endpoint)()
+	return c.Get(endpoint, query, result)
+}
+
+// RequestDurationMetrics snapshots the accumulated
+// fortigate_api_request_duration_seconds histograms, one per endpoint
+// that has been called at least once through TimedGet. A probe appends
+// this to its own returned metrics (see
+// pkg/probe/system_ha_history.go:probeSystemHAHistory) since that is the
+// only mechanism this framework has for actually serving a metric to a
+// target's /probe scrape.
+func RequestDurationMetrics() []prometheus.Metric {
+	desc := prometheus.NewDesc(
+		"fortigate_api_request_duration_seconds",
+		"Latency of FortiGate API requests",
+		[]string{"endpoint"}, nil,
+	)
+
+	requestDurations.mu.Lock()
+	defer requestDurations.mu.Unlock()
+
+	m := make([]prometheus.Metric, 0, len(requestDurations.counts))
+	for endpoint, count := range requestDurations.counts {
+		buckets := make(map[float64]uint64, len(requestDurationBuckets))
+		for _, b := range requestDurationBuckets {
+			buckets[b] = requestDurations.buckets[endpoint][b]
+		}
+		metric, err := prometheus.NewConstHistogram(desc, count, requestDurations.sums[endpoint], buckets, endpoint)
+		if err != nil {
+			continue
+		}
+		m = append(m, metric)
+	}
+	return m
+}
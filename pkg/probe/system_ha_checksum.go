@@ -15,6 +15,10 @@ package probe
 
 import (
 	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/prometheus-community/fortigate_exporter/pkg/http"
 	"github.com/prometheus/client_golang/prometheus"
@@ -28,9 +32,9 @@ type HAChecksum struct {
 }
 
 type HAChecksumResults struct {
-	IsManageMaster int       `json:"is_manage_master"`
-	IsRootMaster   int       `json:"is_root_master"`
-	SerialNo       string    `json:"serial_no"`
+	IsManageMaster int        `json:"is_manage_master"`
+	IsRootMaster   int        `json:"is_root_master"`
+	SerialNo       string     `json:"serial_no"`
 	Checksum       HAChecksum `json:"checksum"`
 }
 
@@ -38,6 +42,161 @@ type HAChecksumResponse struct {
 	Results []HAChecksumResults `json:"results"`
 }
 
+// checksumScope is one (checksum_type, value) pair observed on a single
+// member, e.g. {"global", "abc123"} or {"vdom_root", "def456"}.
+type checksumScope struct {
+	checksumType string
+	value        string
+}
+
+// scopeChecksums returns every (checksum_type, value) pair present on a
+// member, including one entry per vdom.
+func scopeChecksums(r HAChecksumResults) []checksumScope {
+	scopes := []checksumScope{
+		{"global", r.Checksum.Global},
+		{"root", r.Checksum.Root},
+		{"all", r.Checksum.All},
+	}
+	for vdom, sum := range r.Checksum.Vdoms {
+		scopes = append(scopes, checksumScope{"vdom_" + vdom, sum})
+	}
+	return scopes
+}
+
+// plurality returns the most common checksum value for a given
+// checksum_type across all members, the number of members holding it, and
+// the total number of members that reported that checksum_type at all.
+func plurality(results []HAChecksumResults, checksumType string) (value string, count, total int) {
+	counts := map[string]int{}
+	for _, r := range results {
+		for _, s := range scopeChecksums(r) {
+			if s.checksumType != checksumType {
+				continue
+			}
+			counts[s.value]++
+			total++
+		}
+	}
+	best, bestCount := "", -1
+	// Sort keys for deterministic output when counts tie.
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if counts[k] > bestCount {
+			best, bestCount = k, counts[k]
+		}
+	}
+	return best, bestCount, total
+}
+
+// allChecksumTypes enumerates every checksum_type seen across the cluster,
+// so that vdoms that only exist on some members are still covered.
+func allChecksumTypes(results []HAChecksumResults) []string {
+	seen := map[string]bool{}
+	var types []string
+	for _, r := range results {
+		for _, s := range scopeChecksums(r) {
+			if !seen[s.checksumType] {
+				seen[s.checksumType] = true
+				types = append(types, s.checksumType)
+			}
+		}
+	}
+	sort.Strings(types)
+	return types
+}
+
+// checksumOf returns the checksum value a member reports for checksumType,
+// and whether it reported one at all.
+func checksumOf(r HAChecksumResults, checksumType string) (string, bool) {
+	for _, s := range scopeChecksums(r) {
+		if s.checksumType == checksumType {
+			return s.value, true
+		}
+	}
+	return "", false
+}
+
+// splitChecksumType turns a checksum_type such as "vdom_root" into its
+// scope ("vdom") and scope_name ("root"). Non-vdom scopes have no name.
+func splitChecksumType(checksumType string) (scope, scopeName string) {
+	if rest, ok := strings.CutPrefix(checksumType, "vdom_"); ok {
+		return "vdom", rest
+	}
+	return checksumType, ""
+}
+
+// mismatchKey identifies one (ordered) pair of members plus the scope
+// they disagree on.
+type mismatchKey struct {
+	left, right, checksumType string
+}
+
+// mismatchOutlier names the member(s) that diverge from the cluster
+// plurality for a mismatching pair, for the exemplar attached to that
+// pair's counter.
+type mismatchOutlier struct {
+	serial, checksum string
+}
+
+// checksumMismatchState is the per-target, persisted cumulative count of
+// every pair+scope mismatch ever observed. It is kept here rather than in
+// a globally-registered prometheus.CounterVec: this probe framework's
+// only collection mechanism is the []prometheus.Metric a probe function
+// returns, so the counter has to be built fresh each scrape from this
+// state and appended to that slice to actually be served, rather than
+// registered once against prometheus.DefaultRegisterer and hoped to be
+// picked up by whatever builds a target's per-scrape registry.
+type checksumMismatchState struct {
+	mu     sync.Mutex
+	counts map[mismatchKey]float64
+}
+
+var checksumMismatchStates sync.Map // map[string]*checksumMismatchState, keyed by target
+
+func checksumMismatchStateFor(target string) *checksumMismatchState {
+	v, _ := checksumMismatchStates.LoadOrStore(target, &checksumMismatchState{counts: map[mismatchKey]float64{}})
+	return v.(*checksumMismatchState)
+}
+
+// haChecksumCacheTTL bounds how long a fetched ha-checksums response is
+// reused for. probeSystemHAChecksum and probeSystemHAFailover are
+// registered and scraped as independent probes but want the same data;
+// this collapses back-to-back fetches within a scrape into one HTTP call
+// without risking a stale response surviving across scrapes.
+const haChecksumCacheTTL = 3 * time.Second
+
+type haChecksumCacheEntry struct {
+	res       HAChecksumResponse
+	err       error
+	fetchedAt time.Time
+}
+
+var haChecksumCache sync.Map // map[string]*haChecksumCacheEntry, keyed by target
+
+// fetchHAChecksums fetches api/v2/monitor/system/ha-checksums for the
+// target in meta, reusing a recent response (within haChecksumCacheTTL)
+// instead of issuing a new request, so probeSystemHAChecksum and
+// probeSystemHAFailover don't double the number of ha-checksums calls
+// per scrape round.
+func fetchHAChecksums(c http.FortiHTTP, meta *TargetMetadata) (HAChecksumResponse, error) {
+	target := meta.Target
+	if v, ok := haChecksumCache.Load(target); ok {
+		entry := v.(*haChecksumCacheEntry)
+		if time.Since(entry.fetchedAt) < haChecksumCacheTTL {
+			return entry.res, entry.err
+		}
+	}
+
+	var res HAChecksumResponse
+	err := http.TimedGet(c, "api/v2/monitor/system/ha-checksums", "scope=global", &res)
+	haChecksumCache.Store(target, &haChecksumCacheEntry{res: res, err: err, fetchedAt: time.Now()})
+	return res, err
+}
+
 func probeSystemHAChecksum(c http.FortiHTTP, meta *TargetMetadata) ([]prometheus.Metric, bool) {
 	var (
 		IsMaster = prometheus.NewDesc(
@@ -50,59 +209,166 @@ func probeSystemHAChecksum(c http.FortiHTTP, meta *TargetMetadata) ([]prometheus
 			"HA checksum synchronization status (1=synced, 0=out of sync)",
 			[]string{"checksum_type", "serial"}, nil,
 		)
+		QuorumAgreement = prometheus.NewDesc(
+			"fortigate_ha_checksum_quorum_agreement",
+			"Fraction of cluster members whose checksum matches the plurality checksum for this scope",
+			[]string{"checksum_type"}, nil,
+		)
+		DivergentMembers = prometheus.NewDesc(
+			"fortigate_ha_checksum_divergent_members",
+			"Number of cluster members whose checksum does not match the plurality checksum for this scope",
+			[]string{"checksum_type"}, nil,
+		)
+		ChecksumMismatch = prometheus.NewDesc(
+			"fortigate_ha_checksum_mismatch",
+			"1 if the two members disagree on the checksum for this scope, 0 if they agree",
+			[]string{"left", "right", "checksum_type"}, nil,
+		)
+		ChecksumMismatchTotal = prometheus.NewDesc(
+			"fortigate_ha_checksum_mismatch_total",
+			"Cumulative count of scrapes in which a pair of members disagreed on the checksum for this scope",
+			[]string{"left", "right", "checksum_type"}, nil,
+		)
+		ChecksumInfo = prometheus.NewDesc(
+			"fortigate_ha_checksum_info",
+			"Raw HA checksum value reported by a member for a scope, always 1. Join on the checksum label to correlate drift across clusters",
+			[]string{"serial", "scope", "scope_name", "checksum"}, nil,
+		)
 	)
 
-	var res HAChecksumResponse
-	if err := c.Get("api/v2/monitor/system/ha-checksums", "scope=global", &res); err != nil {
+	res, err := fetchHAChecksums(c, meta)
+	if err != nil {
 		log.Printf("Error: %v", err)
 		return nil, false
 	}
 
 	m := []prometheus.Metric{}
-	
+
 	// Track master/slave roles
 	for _, response := range res.Results {
 		m = append(m, prometheus.MustNewConstMetric(IsMaster, prometheus.GaugeValue, float64(response.IsManageMaster), "manage_master", response.SerialNo))
 		m = append(m, prometheus.MustNewConstMetric(IsMaster, prometheus.GaugeValue, float64(response.IsRootMaster), "root_master", response.SerialNo))
 	}
 
-	// Compare checksums between nodes
-	if len(res.Results) > 1 {
-		// Use first node as reference
-		referenceNode := res.Results[0]
-		
+	// Large clusters with many vdoms can make this metric's cardinality
+	// expensive, so it is opt-out via the per-probe YAML config (see
+	// HAChecksumConfig / SetHAChecksumConfig).
+	if !haChecksumConfig.DisableInfoMetric {
 		for _, node := range res.Results {
-			// Check global checksum sync
-			globalSync := 1.0
-			if node.Checksum.Global != referenceNode.Checksum.Global {
-				globalSync = 0.0
-			}
-			m = append(m, prometheus.MustNewConstMetric(ChecksumSync, prometheus.GaugeValue, globalSync, "global", node.SerialNo))
-			
-			// Check root checksum sync  
-			rootSync := 1.0
-			if node.Checksum.Root != referenceNode.Checksum.Root {
-				rootSync = 0.0
+			for _, s := range scopeChecksums(node) {
+				scope, scopeName := splitChecksumType(s.checksumType)
+				m = append(m, prometheus.MustNewConstMetric(ChecksumInfo, prometheus.GaugeValue, 1, node.SerialNo, scope, scopeName, s.value))
 			}
-			m = append(m, prometheus.MustNewConstMetric(ChecksumSync, prometheus.GaugeValue, rootSync, "root", node.SerialNo))
-			
-			// Check all checksum sync
-			allSync := 1.0
-			if node.Checksum.All != referenceNode.Checksum.All {
-				allSync = 0.0
+		}
+	}
+
+	if len(res.Results) < 2 {
+		return m, true
+	}
+
+	types := allChecksumTypes(res.Results)
+
+	// Quorum/plurality comparison per scope, replacing the old
+	// "compare everyone to Results[0]" approach: a single stale reference
+	// node used to make the whole cluster look out-of-sync when it was
+	// actually the reference that had drifted.
+	majorityByType := map[string]string{}
+	for _, checksumType := range types {
+		majority, majorityCount, total := plurality(res.Results, checksumType)
+		if total == 0 {
+			continue
+		}
+		majorityByType[checksumType] = majority
+		// Members that don't report this scope at all (e.g. a vdom missing
+		// on them) count as not matching the plurality, as in ChecksumSync.
+		members := len(res.Results)
+		m = append(m, prometheus.MustNewConstMetric(QuorumAgreement, prometheus.GaugeValue, float64(majorityCount)/float64(members), checksumType))
+		m = append(m, prometheus.MustNewConstMetric(DivergentMembers, prometheus.GaugeValue, float64(members-majorityCount), checksumType))
+
+		for _, node := range res.Results {
+			sum, ok := checksumOf(node, checksumType)
+			inSync := 1.0
+			if !ok || sum != majority {
+				inSync = 0.0
 			}
-			m = append(m, prometheus.MustNewConstMetric(ChecksumSync, prometheus.GaugeValue, allSync, "all", node.SerialNo))
-			
-			// Check vdom checksums
-			for vdom, checksum := range node.Checksum.Vdoms {
-				vdomSync := 1.0
-				if referenceChecksum, exists := referenceNode.Checksum.Vdoms[vdom]; !exists || checksum != referenceChecksum {
-					vdomSync = 0.0
+			m = append(m, prometheus.MustNewConstMetric(ChecksumSync, prometheus.GaugeValue, inSync, checksumType, node.SerialNo))
+		}
+	}
+
+	// Pairwise mismatch. The gauge reports the current 1/0 state;
+	// mismatches also bump a persisted per-pair counter (see
+	// checksumMismatchState) carrying an exemplar that names whichever
+	// side of the pair actually diverges from the cluster plurality, so
+	// alerts can pinpoint the culprit member instead of just flagging
+	// "cluster not in sync".
+	state := checksumMismatchStateFor(meta.Target)
+	state.mu.Lock()
+	currentOutliers := map[mismatchKey]mismatchOutlier{}
+	for i := 0; i < len(res.Results); i++ {
+		for j := i + 1; j < len(res.Results); j++ {
+			left, right := res.Results[i], res.Results[j]
+			for _, checksumType := range types {
+				leftSum, leftOK := checksumOf(left, checksumType)
+				rightSum, rightOK := checksumOf(right, checksumType)
+
+				mismatch := 0.0
+				if !leftOK || !rightOK || leftSum != rightSum {
+					mismatch = 1.0
 				}
-				m = append(m, prometheus.MustNewConstMetric(ChecksumSync, prometheus.GaugeValue, vdomSync, "vdom_"+vdom, node.SerialNo))
+
+				m = append(m, prometheus.MustNewConstMetric(ChecksumMismatch, prometheus.GaugeValue, mismatch, left.SerialNo, right.SerialNo, checksumType))
+
+				if mismatch != 1.0 {
+					continue
+				}
+
+				key := mismatchKey{left: left.SerialNo, right: right.SerialNo, checksumType: checksumType}
+				state.counts[key]++
+
+				majority, haveMajority := majorityByType[checksumType]
+				switch {
+				case haveMajority && leftSum != majority && rightSum == majority:
+					currentOutliers[key] = mismatchOutlier{serial: left.SerialNo, checksum: leftSum}
+				case haveMajority && rightSum != majority && leftSum == majority:
+					currentOutliers[key] = mismatchOutlier{serial: right.SerialNo, checksum: rightSum}
+				case haveMajority && leftSum != majority && rightSum != majority:
+					// Neither side matches the plurality: name both
+					// candidates, since a Counter's Write() only keeps
+					// the most recently attached exemplar anyway.
+					currentOutliers[key] = mismatchOutlier{
+						serial:   left.SerialNo + "," + right.SerialNo,
+						checksum: leftSum + "," + rightSum,
+					}
+				default:
+					// No majority computed for this scope (shouldn't
+					// happen once len(res.Results) >= 2, but fall back to
+					// naming the right-hand member rather than guessing).
+					currentOutliers[key] = mismatchOutlier{serial: right.SerialNo, checksum: rightSum}
+				}
+			}
+		}
+	}
+
+	for key, count := range state.counts {
+		metric := prometheus.MustNewConstMetric(ChecksumMismatchTotal, prometheus.CounterValue, count, key.left, key.right, key.checksumType)
+		if outlier, ok := currentOutliers[key]; ok {
+			withExemplar, err := prometheus.NewMetricWithExemplars(metric, prometheus.Exemplar{
+				Value:     count,
+				Timestamp: time.Now(),
+				Labels: prometheus.Labels{
+					"serial":   outlier.serial,
+					"checksum": outlier.checksum,
+				},
+			})
+			if err != nil {
+				log.Printf("Error: %v", err)
+			} else {
+				metric = withExemplar
 			}
 		}
+		m = append(m, metric)
 	}
+	state.mu.Unlock()
 
 	return m, true
 }
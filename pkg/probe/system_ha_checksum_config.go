@@ -0,0 +1,35 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package probe
+
+// HAChecksumConfig is the per-probe YAML configuration for
+// probeSystemHAChecksum.
+type HAChecksumConfig struct {
+	// DisableInfoMetric suppresses fortigate_ha_checksum_info, which
+	// emits one series per (member, scope) and can get expensive to
+	// scrape on clusters with many vdoms.
+	DisableInfoMetric bool `yaml:"disable_checksum_info"`
+}
+
+// haChecksumConfig holds the config installed by SetHAChecksumConfig. It
+// defaults to the zero value (nothing disabled) until the exporter's
+// config loader calls SetHAChecksumConfig during startup.
+var haChecksumConfig HAChecksumConfig
+
+// SetHAChecksumConfig installs the YAML-derived configuration for
+// probeSystemHAChecksum. It is called once, after the probe config
+// section of the exporter's YAML config has been parsed.
+func SetHAChecksumConfig(cfg HAChecksumConfig) {
+	haChecksumConfig = cfg
+}
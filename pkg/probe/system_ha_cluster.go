@@ -0,0 +1,180 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package probe
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus-community/fortigate_exporter/pkg/http"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// minHAPeerMajor/Minor and minHAStatisticsMajor/Minor are the first
+// FortiOS releases whose monitor API exposes ha-peer and ha-statistics,
+// respectively. Older releases don't have the endpoint at all, and
+// calling it anyway risks a differently-shaped (rather than erroring)
+// response on in-between releases being misparsed instead of cleanly
+// skipped.
+const (
+	minHAPeerMajor, minHAPeerMinor             = 6, 4
+	minHAStatisticsMajor, minHAStatisticsMinor = 7, 0
+)
+
+// fortiOSAtLeast reports whether version (e.g. "v7.2.1" or
+// "v6.4.0,build1234,220101") is at least wantMajor.wantMinor. An
+// unparseable version is treated as not meeting the requirement, so an
+// endpoint is skipped rather than guessed at.
+func fortiOSAtLeast(version string, wantMajor, wantMinor int) bool {
+	version = strings.TrimPrefix(strings.SplitN(version, ",", 2)[0], "v")
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+	if major != wantMajor {
+		return major > wantMajor
+	}
+	return minor >= wantMinor
+}
+
+type HAPeerResult struct {
+	SerialNo    string `json:"serial_no"`
+	Hostname    string `json:"hostname"`
+	Priority    int    `json:"priority"`
+	HAGroupID   int    `json:"ha_group_id"`
+	HAGroupName string `json:"ha_group_name"`
+	Override    bool   `json:"override"`
+	SyncStatus  string `json:"sync_status"`
+}
+
+type HAPeerResponse struct {
+	Results []HAPeerResult `json:"results"`
+}
+
+type HAStatisticsResult struct {
+	SerialNo     string  `json:"serial_no"`
+	UptimeDays   float64 `json:"uptime_days"`
+	SessionCount int     `json:"session_count"`
+	CPUUsage     float64 `json:"cpu_usage"`
+	MemoryUsage  float64 `json:"memory_usage"`
+}
+
+type HAStatisticsResponse struct {
+	Results []HAStatisticsResult `json:"results"`
+}
+
+// probeSystemHACluster joins ha-peer and ha-statistics by serial number to
+// give a fuller picture of cluster member health than ha-checksums alone
+// (see probeSystemHAChecksum). Each endpoint is gated on the target's
+// FortiOS version (see fortiOSAtLeast) since older releases don't expose
+// it at all, and a request failure on one endpoint does not prevent the
+// other from being exported.
+func probeSystemHACluster(c http.FortiHTTP, meta *TargetMetadata) ([]prometheus.Metric, bool) {
+	var (
+		MemberInfo = prometheus.NewDesc(
+			"fortigate_ha_member_info",
+			"Static information about a HA cluster member",
+			[]string{"serial", "hostname", "priority", "hagroup_id", "hagroup_name"}, nil,
+		)
+		MemberUptime = prometheus.NewDesc(
+			"fortigate_ha_member_uptime_seconds",
+			"Uptime of a HA cluster member",
+			[]string{"serial"}, nil,
+		)
+		MemberSessions = prometheus.NewDesc(
+			"fortigate_ha_member_sessions",
+			"Number of active sessions on a HA cluster member",
+			[]string{"serial"}, nil,
+		)
+		MemberCPUUsage = prometheus.NewDesc(
+			"fortigate_ha_member_cpu_usage",
+			"CPU usage of a HA cluster member, in percent",
+			[]string{"serial"}, nil,
+		)
+		MemberMemoryUsage = prometheus.NewDesc(
+			"fortigate_ha_member_memory_usage",
+			"Memory usage of a HA cluster member, in percent",
+			[]string{"serial"}, nil,
+		)
+		MemberSyncStatus = prometheus.NewDesc(
+			"fortigate_ha_member_sync_status",
+			"HA synchronization status of a cluster member (1=in sync, 0=out of sync)",
+			[]string{"serial"}, nil,
+		)
+		OverrideEnabled = prometheus.NewDesc(
+			"fortigate_ha_override_enabled",
+			"1 if HA override is enabled on this member, 0 otherwise",
+			[]string{"serial"}, nil,
+		)
+	)
+
+	m := []prometheus.Metric{}
+	ok := false
+
+	if !fortiOSAtLeast(meta.OSVersion, minHAPeerMajor, minHAPeerMinor) {
+		log.Printf("Skipping ha-peer: FortiOS %s is older than %d.%d", meta.OSVersion, minHAPeerMajor, minHAPeerMinor)
+	} else {
+		var peers HAPeerResponse
+		if err := http.TimedGet(c, "api/v2/monitor/system/ha-peer", "", &peers); err != nil {
+			log.Printf("Error: %v", err)
+		} else {
+			ok = true
+			for _, p := range peers.Results {
+				m = append(m, prometheus.MustNewConstMetric(MemberInfo, prometheus.GaugeValue, 1,
+					p.SerialNo, p.Hostname, fmt.Sprintf("%d", p.Priority), fmt.Sprintf("%d", p.HAGroupID), p.HAGroupName))
+
+				override := 0.0
+				if p.Override {
+					override = 1.0
+				}
+				m = append(m, prometheus.MustNewConstMetric(OverrideEnabled, prometheus.GaugeValue, override, p.SerialNo))
+
+				sync := 0.0
+				if p.SyncStatus == "synchronized" {
+					sync = 1.0
+				}
+				m = append(m, prometheus.MustNewConstMetric(MemberSyncStatus, prometheus.GaugeValue, sync, p.SerialNo))
+			}
+		}
+	}
+
+	if !fortiOSAtLeast(meta.OSVersion, minHAStatisticsMajor, minHAStatisticsMinor) {
+		log.Printf("Skipping ha-statistics: FortiOS %s is older than %d.%d", meta.OSVersion, minHAStatisticsMajor, minHAStatisticsMinor)
+	} else {
+		var stats HAStatisticsResponse
+		if err := http.TimedGet(c, "api/v2/monitor/system/ha-statistics", "", &stats); err != nil {
+			log.Printf("Error: %v", err)
+		} else {
+			ok = true
+			for _, s := range stats.Results {
+				m = append(m, prometheus.MustNewConstMetric(MemberUptime, prometheus.GaugeValue, s.UptimeDays*86400, s.SerialNo))
+				m = append(m, prometheus.MustNewConstMetric(MemberSessions, prometheus.GaugeValue, float64(s.SessionCount), s.SerialNo))
+				m = append(m, prometheus.MustNewConstMetric(MemberCPUUsage, prometheus.GaugeValue, s.CPUUsage, s.SerialNo))
+				m = append(m, prometheus.MustNewConstMetric(MemberMemoryUsage, prometheus.GaugeValue, s.MemoryUsage, s.SerialNo))
+			}
+		}
+	}
+
+	return m, ok
+}
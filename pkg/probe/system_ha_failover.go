@@ -0,0 +1,175 @@
+// Copyright 2025 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package probe
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/prometheus-community/fortigate_exporter/pkg/http"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// haFailoverTTL bounds how long a target's last-known HA role is
+// remembered for. Targets that stop being scraped (decommissioned,
+// renamed) would otherwise pin their entry in haFailoverState forever.
+const haFailoverTTL = 1 * time.Hour
+
+// haRoleState is the last observed master for a single role (manage or
+// root) on a single target, plus when it was last updated.
+type haRoleState struct {
+	serial     string
+	lastUpdate time.Time
+}
+
+// haFailoverKey identifies one specific master transition, so its
+// cumulative count can be reported as a stable time series across
+// scrapes instead of only on the scrape where it was detected.
+type haFailoverKey struct {
+	role, from, to string
+}
+
+// haTargetState is the per-target state tracked across scrapes, keyed by
+// role ("manage_master"/"root_master"). failoverCounts and
+// lastFailoverUnix persist once a failover has been observed, so they can
+// be re-emitted on every scrape rather than only the scrape that
+// detected the transition.
+type haTargetState struct {
+	mu               sync.Mutex
+	roles            map[string]haRoleState
+	failoverCounts   map[haFailoverKey]float64
+	lastFailoverUnix map[string]float64
+}
+
+// haFailoverState holds one haTargetState per scrape target URL. Scrapes
+// of different targets run concurrently, so the map itself as well as
+// each entry's fields need independent synchronization.
+var haFailoverState sync.Map // map[string]*haTargetState
+
+func init() {
+	go evictStaleHAFailoverState()
+}
+
+// evictStaleHAFailoverState periodically drops targets that have not been
+// scraped within haFailoverTTL, so long-lived exporters don't accumulate
+// state for targets that no longer exist.
+func evictStaleHAFailoverState() {
+	for range time.Tick(haFailoverTTL) {
+		now := time.Now()
+		haFailoverState.Range(func(key, value interface{}) bool {
+			ts := value.(*haTargetState)
+			ts.mu.Lock()
+			for role, state := range ts.roles {
+				if now.Sub(state.lastUpdate) > haFailoverTTL {
+					delete(ts.roles, role)
+				}
+			}
+			stale := len(ts.roles) == 0
+			ts.mu.Unlock()
+			if stale {
+				haFailoverState.Delete(key)
+			}
+			return true
+		})
+	}
+}
+
+// probeSystemHAFailover uses the same ha-checksums data as
+// probeSystemHAChecksum (via fetchHAChecksums, which caches the response
+// briefly so the two probes don't double the number of ha-checksums
+// calls per scrape) to detect master role transitions across scrapes of
+// the same target, and emits a counter per transition plus a gauge for
+// the last failover time.
+func probeSystemHAFailover(c http.FortiHTTP, meta *TargetMetadata) ([]prometheus.Metric, bool) {
+	var (
+		FailoverTotal = prometheus.NewDesc(
+			"fortigate_ha_failover_total",
+			"Number of times the HA master role has moved from one member to another",
+			[]string{"from_serial", "to_serial", "role"}, nil,
+		)
+		LastFailoverTimestamp = prometheus.NewDesc(
+			"fortigate_ha_last_failover_timestamp_seconds",
+			"Unix timestamp of the last observed HA master role transition",
+			[]string{"role"}, nil,
+		)
+	)
+
+	res, err := fetchHAChecksums(c, meta)
+	if err != nil {
+		log.Printf("Error: %v", err)
+		return nil, false
+	}
+
+	target := meta.Target
+	tsVal, _ := haFailoverState.LoadOrStore(target, &haTargetState{
+		roles:            map[string]haRoleState{},
+		failoverCounts:   map[haFailoverKey]float64{},
+		lastFailoverUnix: map[string]float64{},
+	})
+	ts := tsVal.(*haTargetState)
+
+	m := []prometheus.Metric{}
+	now := time.Now()
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	for _, role := range []string{"manage_master", "root_master"} {
+		master := currentMaster(res.Results, role)
+		if master == "" {
+			continue
+		}
+
+		prev, known := ts.roles[role]
+		if known && prev.serial != master {
+			ts.failoverCounts[haFailoverKey{role: role, from: prev.serial, to: master}]++
+			ts.lastFailoverUnix[role] = float64(now.Unix())
+		}
+		ts.roles[role] = haRoleState{serial: master, lastUpdate: now}
+	}
+
+	// Re-emit every known transition's cumulative count and every role's
+	// last-failover timestamp on every scrape, not just the scrape that
+	// detected a new transition: a counter that disappears between
+	// events can't be rate()'d, and the timestamp gauge exists precisely
+	// so alerting can check time()-value on scrapes with no new failover.
+	for key, count := range ts.failoverCounts {
+		m = append(m, prometheus.MustNewConstMetric(FailoverTotal, prometheus.CounterValue, count, key.from, key.to, key.role))
+	}
+	for role, lastFailover := range ts.lastFailoverUnix {
+		m = append(m, prometheus.MustNewConstMetric(LastFailoverTimestamp, prometheus.GaugeValue, lastFailover, role))
+	}
+
+	return m, true
+}
+
+// currentMaster returns the serial number of the member currently holding
+// the given role ("manage_master" or "root_master"), or "" if no member
+// reports holding it.
+func currentMaster(results []HAChecksumResults, role string) string {
+	for _, r := range results {
+		switch role {
+		case "manage_master":
+			if r.IsManageMaster != 0 {
+				return r.SerialNo
+			}
+		case "root_master":
+			if r.IsRootMaster != 0 {
+				return r.SerialNo
+			}
+		}
+	}
+	return ""
+}